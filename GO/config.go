@@ -0,0 +1,228 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLSConfig holds the TLS-related settings resolved by Config.
+type TLSConfig struct {
+    Enabled bool
+    // CertFile and KeyFile point at an existing PEM-encoded certificate and
+    // key. When either is empty, a self-signed pair is generated on first
+    // run and cached under CacheDir.
+    CertFile string
+    KeyFile  string
+    CacheDir string
+    // RedirectPort is the secondary HTTP listener that redirects to the
+    // HTTPS port, started alongside the TLS listener.
+    RedirectPort int
+}
+
+// Config holds everything NewWebServer needs to build a server. It is
+// resolved by LoadConfig from, in order of increasing precedence: built-in
+// defaults, an optional --config YAML file, environment variables, and
+// command-line flags.
+type Config struct {
+    Host            string
+    Port            int
+    ReadTimeout     time.Duration
+    WriteTimeout    time.Duration
+    IdleTimeout     time.Duration
+    ShutdownTimeout time.Duration
+    TLS             TLSConfig
+
+    // Dev, when true, reloads templates and static assets from disk on
+    // every request instead of serving the versions embedded at build time.
+    Dev bool
+}
+
+// defaultConfig returns the built-in defaults applied before any other source.
+func defaultConfig() *Config {
+    return &Config{
+        Host:            HOST,
+        Port:            PORT,
+        ReadTimeout:     10 * time.Second,
+        WriteTimeout:    10 * time.Second,
+        IdleTimeout:     120 * time.Second,
+        ShutdownTimeout: defaultShutdownTimeout,
+        TLS: TLSConfig{
+            CacheDir:     defaultTLSCacheDir,
+            RedirectPort: defaultTLSRedirectPort,
+        },
+    }
+}
+
+// LoadConfig resolves a Config from args (typically os.Args[1:]), layering
+// an optional --config YAML file, XWEB_* environment variables, and flags on
+// top of defaultConfig, in that order of increasing precedence.
+func LoadConfig(args []string) (*Config, error) {
+    cfg := defaultConfig()
+
+    fs := flag.NewFlagSet("xweb", flag.ContinueOnError)
+    configPath := fs.String("config", "", "path to a YAML config file")
+    host := fs.String("host", "", "host to listen on")
+    port := fs.Int("port", 0, "port to listen on")
+    tlsEnabled := fs.Bool("tls", false, "enable TLS")
+    tlsCert := fs.String("tls-cert", "", "path to a TLS certificate")
+    tlsKey := fs.String("tls-key", "", "path to a TLS private key")
+    tlsCacheDir := fs.String("tls-cache-dir", "", "directory to cache an auto-generated self-signed certificate in")
+    tlsRedirectPort := fs.Int("tls-redirect-port", 0, "port for the HTTP-to-HTTPS redirect listener")
+    dev := fs.Bool("dev", false, "reload templates and static assets from disk on every request")
+
+    if err := fs.Parse(args); err != nil {
+        return nil, err
+    }
+
+    if *configPath != "" {
+        if err := applyYAMLFile(cfg, *configPath); err != nil {
+            return nil, fmt.Errorf("loading config file %s: %w", *configPath, err)
+        }
+    }
+
+    applyEnv(cfg)
+
+    fs.Visit(func(f *flag.Flag) {
+        switch f.Name {
+        case "host":
+            cfg.Host = *host
+        case "port":
+            cfg.Port = *port
+        case "tls":
+            cfg.TLS.Enabled = *tlsEnabled
+        case "tls-cert":
+            cfg.TLS.CertFile = *tlsCert
+        case "tls-key":
+            cfg.TLS.KeyFile = *tlsKey
+        case "tls-cache-dir":
+            cfg.TLS.CacheDir = *tlsCacheDir
+        case "tls-redirect-port":
+            cfg.TLS.RedirectPort = *tlsRedirectPort
+        case "dev":
+            cfg.Dev = *dev
+        }
+    })
+
+    return cfg, nil
+}
+
+// applyEnv overlays any XWEB_* environment variables onto cfg.
+func applyEnv(cfg *Config) {
+    if v := os.Getenv("XWEB_HOST"); v != "" {
+        cfg.Host = v
+    }
+    if v := os.Getenv("XWEB_PORT"); v != "" {
+        if port, err := strconv.Atoi(v); err == nil {
+            cfg.Port = port
+        }
+    }
+    if v := os.Getenv("XWEB_READ_TIMEOUT"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            cfg.ReadTimeout = d
+        }
+    }
+    if v := os.Getenv("XWEB_WRITE_TIMEOUT"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            cfg.WriteTimeout = d
+        }
+    }
+    if v := os.Getenv("XWEB_IDLE_TIMEOUT"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            cfg.IdleTimeout = d
+        }
+    }
+    if v := os.Getenv("XWEB_SHUTDOWN_TIMEOUT"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            cfg.ShutdownTimeout = d
+        }
+    }
+    if v := os.Getenv("XWEB_TLS_ENABLED"); v != "" {
+        cfg.TLS.Enabled = v == "true" || v == "1"
+    }
+    if v := os.Getenv("XWEB_TLS_CERT"); v != "" {
+        cfg.TLS.CertFile = v
+    }
+    if v := os.Getenv("XWEB_TLS_KEY"); v != "" {
+        cfg.TLS.KeyFile = v
+    }
+    if v := os.Getenv("XWEB_TLS_CACHE_DIR"); v != "" {
+        cfg.TLS.CacheDir = v
+    }
+    if v := os.Getenv("XWEB_TLS_REDIRECT_PORT"); v != "" {
+        if port, err := strconv.Atoi(v); err == nil {
+            cfg.TLS.RedirectPort = port
+        }
+    }
+    if v := os.Getenv("XWEB_DEV"); v != "" {
+        cfg.Dev = v == "true" || v == "1"
+    }
+}
+
+// applyYAMLFile overlays settings parsed from a YAML config file onto cfg.
+// Only the flat "key: value" subset of YAML needed by this project's sample
+// configs is supported, so the module carries no external YAML dependency.
+func applyYAMLFile(cfg *Config, path string) error {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return err
+    }
+
+    for _, line := range strings.Split(string(data), "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+
+        key, value, ok := strings.Cut(line, ":")
+        if !ok {
+            continue
+        }
+        key = strings.TrimSpace(key)
+        value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+        switch key {
+        case "host":
+            cfg.Host = value
+        case "port":
+            if port, err := strconv.Atoi(value); err == nil {
+                cfg.Port = port
+            }
+        case "read_timeout":
+            if d, err := time.ParseDuration(value); err == nil {
+                cfg.ReadTimeout = d
+            }
+        case "write_timeout":
+            if d, err := time.ParseDuration(value); err == nil {
+                cfg.WriteTimeout = d
+            }
+        case "idle_timeout":
+            if d, err := time.ParseDuration(value); err == nil {
+                cfg.IdleTimeout = d
+            }
+        case "shutdown_timeout":
+            if d, err := time.ParseDuration(value); err == nil {
+                cfg.ShutdownTimeout = d
+            }
+        case "tls_enabled":
+            cfg.TLS.Enabled = value == "true"
+        case "tls_cert":
+            cfg.TLS.CertFile = value
+        case "tls_key":
+            cfg.TLS.KeyFile = value
+        case "tls_cache_dir":
+            cfg.TLS.CacheDir = value
+        case "tls_redirect_port":
+            if port, err := strconv.Atoi(value); err == nil {
+                cfg.TLS.RedirectPort = port
+            }
+        case "dev":
+            cfg.Dev = value == "true"
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+// TestApplyYAMLFile checks that the flat key:value parser overlays every
+// recognized field onto an existing Config, ignoring blank lines and comments.
+func TestApplyYAMLFile(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "config.yaml")
+    content := `# sample config
+host: "example.com"
+port: 9090
+
+read_timeout: 5s
+tls_enabled: true
+tls_redirect_port: 8000
+dev: true
+`
+    if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+        t.Fatalf("writing config file: %v", err)
+    }
+
+    cfg := defaultConfig()
+    if err := applyYAMLFile(cfg, path); err != nil {
+        t.Fatalf("applyYAMLFile: %v", err)
+    }
+
+    if cfg.Host != "example.com" {
+        t.Errorf("Host = %q, want %q", cfg.Host, "example.com")
+    }
+    if cfg.Port != 9090 {
+        t.Errorf("Port = %d, want 9090", cfg.Port)
+    }
+    if cfg.ReadTimeout != 5*time.Second {
+        t.Errorf("ReadTimeout = %v, want 5s", cfg.ReadTimeout)
+    }
+    if !cfg.TLS.Enabled {
+        t.Error("TLS.Enabled = false, want true")
+    }
+    if cfg.TLS.RedirectPort != 8000 {
+        t.Errorf("TLS.RedirectPort = %d, want 8000", cfg.TLS.RedirectPort)
+    }
+    if !cfg.Dev {
+        t.Error("Dev = false, want true")
+    }
+}
+
+// TestLoadConfigPrecedence checks the layering LoadConfig applies: an env var
+// wins over the YAML file for host, and a flag wins over the YAML file for port.
+func TestLoadConfigPrecedence(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "config.yaml")
+    if err := os.WriteFile(path, []byte("host: yaml-host\nport: 1111\n"), 0o644); err != nil {
+        t.Fatalf("writing config file: %v", err)
+    }
+
+    t.Setenv("XWEB_HOST", "env-host")
+
+    cfg, err := LoadConfig([]string{"--config", path, "--port", "2222"})
+    if err != nil {
+        t.Fatalf("LoadConfig: %v", err)
+    }
+
+    if cfg.Host != "env-host" {
+        t.Errorf("Host = %q, want %q (env should beat yaml)", cfg.Host, "env-host")
+    }
+    if cfg.Port != 2222 {
+        t.Errorf("Port = %d, want 2222 (flag should beat yaml)", cfg.Port)
+    }
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// durationBucketBounds are the histogram bucket upper bounds (in seconds)
+// used for http_request_duration_seconds, modeled on Prometheus's own defaults.
+var durationBucketBounds = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// httpRequestLabel identifies one http_requests_total series.
+type httpRequestLabel struct {
+    method string
+    path   string
+    status int
+}
+
+// metricsRegistry is a small in-process Prometheus-style registry: just
+// enough counters and histograms to expose /metrics in the text exposition
+// format, so the module has no hard dependency on prometheus/client_golang
+// while still interoperating with any scraper that speaks that format.
+type metricsRegistry struct {
+    mu              sync.Mutex
+    requestTotals   map[httpRequestLabel]int64
+    durationSums    map[string]float64
+    durationCounts  map[string]int64
+    durationBuckets map[string]map[float64]int64
+}
+
+// newMetricsRegistry returns an empty registry ready to record observations.
+func newMetricsRegistry() *metricsRegistry {
+    return &metricsRegistry{
+        requestTotals:   make(map[httpRequestLabel]int64),
+        durationSums:    make(map[string]float64),
+        durationCounts:  make(map[string]int64),
+        durationBuckets: make(map[string]map[float64]int64),
+    }
+}
+
+// observe records one completed request against the registry.
+func (m *metricsRegistry) observe(method, path string, status int, duration time.Duration) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    m.requestTotals[httpRequestLabel{method: method, path: path, status: status}]++
+
+    seconds := duration.Seconds()
+    m.durationSums[path] += seconds
+    m.durationCounts[path]++
+
+    buckets, ok := m.durationBuckets[path]
+    if !ok {
+        buckets = make(map[float64]int64)
+        m.durationBuckets[path] = buckets
+    }
+    for _, bound := range durationBucketBounds {
+        if seconds <= bound {
+            buckets[bound]++
+        }
+    }
+}
+
+// metricsMiddleware records every request's method, path, status, and
+// duration into ws.metrics, so no individual handler needs to instrument itself.
+func (ws *WebServer) metricsMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        start := time.Now()
+        rec := &statusRecorder{ResponseWriter: w}
+
+        next.ServeHTTP(rec, r)
+
+        if rec.status == 0 {
+            rec.status = http.StatusOK
+        }
+        ws.metrics.observe(r.Method, r.URL.Path, rec.status, time.Since(start))
+    })
+}
+
+// handleMetrics exposes counters and histograms in Prometheus text exposition format.
+func (ws *WebServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+    ws.writeMetrics(w)
+}
+
+// writeMetrics renders the registry plus a handful of runtime gauges.
+func (ws *WebServer) writeMetrics(w io.Writer) {
+    m := ws.metrics
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    fmt.Fprintln(w, "# HELP http_requests_total Total number of HTTP requests.")
+    fmt.Fprintln(w, "# TYPE http_requests_total counter")
+    labels := make([]httpRequestLabel, 0, len(m.requestTotals))
+    for l := range m.requestTotals {
+        labels = append(labels, l)
+    }
+    sort.Slice(labels, func(i, j int) bool {
+        if labels[i].path != labels[j].path {
+            return labels[i].path < labels[j].path
+        }
+        if labels[i].method != labels[j].method {
+            return labels[i].method < labels[j].method
+        }
+        return labels[i].status < labels[j].status
+    })
+    for _, l := range labels {
+        fmt.Fprintf(w, "http_requests_total{method=%q,path=%q,status=\"%d\"} %d\n",
+            l.method, l.path, l.status, m.requestTotals[l])
+    }
+
+    fmt.Fprintln(w, "# HELP http_request_duration_seconds Histogram of HTTP request durations.")
+    fmt.Fprintln(w, "# TYPE http_request_duration_seconds histogram")
+    paths := make([]string, 0, len(m.durationCounts))
+    for p := range m.durationCounts {
+        paths = append(paths, p)
+    }
+    sort.Strings(paths)
+    for _, p := range paths {
+        // durationBuckets[p][bound] is already a cumulative count (observe
+        // increments every bound a duration falls under), so it's printed
+        // as-is rather than re-summed here.
+        for _, bound := range durationBucketBounds {
+            fmt.Fprintf(w, "http_request_duration_seconds_bucket{path=%q,le=%q} %d\n",
+                p, strconv.FormatFloat(bound, 'f', -1, 64), m.durationBuckets[p][bound])
+        }
+        fmt.Fprintf(w, "http_request_duration_seconds_bucket{path=%q,le=\"+Inf\"} %d\n", p, m.durationCounts[p])
+        fmt.Fprintf(w, "http_request_duration_seconds_sum{path=%q} %s\n",
+            p, strconv.FormatFloat(m.durationSums[p], 'f', -1, 64))
+        fmt.Fprintf(w, "http_request_duration_seconds_count{path=%q} %d\n", p, m.durationCounts[p])
+    }
+
+    var mem runtime.MemStats
+    runtime.ReadMemStats(&mem)
+
+    fmt.Fprintln(w, "# HELP go_goroutines Number of goroutines currently running.")
+    fmt.Fprintln(w, "# TYPE go_goroutines gauge")
+    fmt.Fprintf(w, "go_goroutines %d\n", runtime.NumGoroutine())
+
+    fmt.Fprintln(w, "# HELP go_memstats_alloc_bytes Bytes of allocated heap objects.")
+    fmt.Fprintln(w, "# TYPE go_memstats_alloc_bytes gauge")
+    fmt.Fprintf(w, "go_memstats_alloc_bytes %d\n", mem.Alloc)
+
+    fmt.Fprintln(w, "# HELP go_memstats_sys_bytes Bytes obtained from the OS.")
+    fmt.Fprintln(w, "# TYPE go_memstats_sys_bytes gauge")
+    fmt.Fprintf(w, "go_memstats_sys_bytes %d\n", mem.Sys)
+
+    fmt.Fprintln(w, "# HELP go_memstats_num_gc Number of completed GC cycles.")
+    fmt.Fprintln(w, "# TYPE go_memstats_num_gc counter")
+    fmt.Fprintf(w, "go_memstats_num_gc %d\n", mem.NumGC)
+
+    fmt.Fprintln(w, "# HELP process_uptime_seconds Seconds since the process started.")
+    fmt.Fprintln(w, "# TYPE process_uptime_seconds gauge")
+    fmt.Fprintf(w, "process_uptime_seconds %f\n", time.Since(ws.startTime).Seconds())
+}
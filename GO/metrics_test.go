@@ -0,0 +1,57 @@
+package main
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+// TestMetricsRegistryObserve checks that observe accumulates per-bound
+// histogram counts correctly: a duration should be counted in every bucket
+// bound it is less than or equal to.
+func TestMetricsRegistryObserve(t *testing.T) {
+    m := newMetricsRegistry()
+
+    m.observe("GET", "/api", 200, 3*time.Millisecond)
+
+    buckets := m.durationBuckets["/api"]
+    for _, bound := range durationBucketBounds {
+        want := int64(0)
+        if 0.003 <= bound {
+            want = 1
+        }
+        if got := buckets[bound]; got != want {
+            t.Errorf("bucket le=%v: got %d, want %d", bound, got, want)
+        }
+    }
+    if got := m.durationCounts["/api"]; got != 1 {
+        t.Errorf("durationCounts[/api] = %d, want 1", got)
+    }
+}
+
+// TestWriteMetricsBucketInvariant verifies the Prometheus histogram
+// invariant that every finite le bucket is <= the +Inf/count total. This is
+// exactly the property the earlier double-accumulation bug violated.
+func TestWriteMetricsBucketInvariant(t *testing.T) {
+    ws := &WebServer{metrics: newMetricsRegistry(), startTime: time.Now()}
+
+    ws.metrics.observe("GET", "/", 200, 3*time.Millisecond)
+    ws.metrics.observe("GET", "/", 200, 50*time.Millisecond)
+    ws.metrics.observe("GET", "/", 200, 20*time.Second)
+
+    var buf strings.Builder
+    ws.writeMetrics(&buf)
+    out := buf.String()
+
+    count := ws.metrics.durationCounts["/"]
+    for _, bound := range durationBucketBounds {
+        bucket := ws.metrics.durationBuckets["/"][bound]
+        if bucket > count {
+            t.Errorf("bucket le=%v (%d) exceeds total count (%d)", bound, bucket, count)
+        }
+    }
+
+    if !strings.Contains(out, `http_request_duration_seconds_bucket{path="/",le="+Inf"} 3`) {
+        t.Errorf("expected +Inf bucket to equal total count 3, got output:\n%s", out)
+    }
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Middleware wraps an http.Handler to add cross-cutting behavior such as
+// logging, panic recovery, or compression.
+type Middleware func(http.Handler) http.Handler
+
+// Use registers an additional middleware. Middlewares wrap the final handler
+// in registration order, so the first one registered ends up outermost.
+func (ws *WebServer) Use(mw Middleware) {
+    ws.middlewares = append(ws.middlewares, mw)
+}
+
+// buildHandler composes the registered middlewares around ws.mux.
+func (ws *WebServer) buildHandler() http.Handler {
+    var handler http.Handler = ws.mux
+    for i := len(ws.middlewares) - 1; i >= 0; i-- {
+        handler = ws.middlewares[i](handler)
+    }
+    return handler
+}
+
+type requestIDKeyType struct{}
+
+var requestIDKey requestIDKeyType
+
+// requestIDMiddleware assigns each request a unique ID, reusing an inbound
+// X-Request-ID header when the caller already supplied one.
+func requestIDMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        id := r.Header.Get("X-Request-ID")
+        if id == "" {
+            id = newRequestID()
+        }
+        w.Header().Set("X-Request-ID", id)
+        next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+    })
+}
+
+// requestIDFromContext returns the request ID stashed by requestIDMiddleware, if any.
+func requestIDFromContext(ctx context.Context) string {
+    id, _ := ctx.Value(requestIDKey).(string)
+    return id
+}
+
+// newRequestID returns a random hex request ID, falling back to a timestamp
+// if the system entropy source is unavailable.
+func newRequestID() string {
+    buf := make([]byte, 8)
+    if _, err := rand.Read(buf); err != nil {
+        return strconv.FormatInt(time.Now().UnixNano(), 16)
+    }
+    return hex.EncodeToString(buf)
+}
+
+// recoveryMiddleware turns a panic in an inner handler into a 500 response
+// instead of crashing the server, logging the stack trace for diagnosis.
+func (ws *WebServer) recoveryMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        defer func() {
+            if rec := recover(); rec != nil {
+                ws.logger.Error("panic recovered",
+                    "error", rec,
+                    "stack", string(debug.Stack()),
+                    "request_id", requestIDFromContext(r.Context()),
+                )
+                http.Error(w, "Internal server error", http.StatusInternalServerError)
+            }
+        }()
+        next.ServeHTTP(w, r)
+    })
+}
+
+// statusRecorder captures the status code and byte count written by an
+// inner handler so loggingMiddleware can report them after the fact.
+type statusRecorder struct {
+    http.ResponseWriter
+    status int
+    bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+    s.status = code
+    s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+    if s.status == 0 {
+        s.status = http.StatusOK
+    }
+    n, err := s.ResponseWriter.Write(b)
+    s.bytes += n
+    return n, err
+}
+
+// Hijack lets the /ws upgrade reach through the logging wrapper.
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+    hj, ok := s.ResponseWriter.(http.Hijacker)
+    if !ok {
+        return nil, nil, fmt.Errorf("underlying ResponseWriter does not support hijacking")
+    }
+    return hj.Hijack()
+}
+
+// loggingMiddleware records one structured access log line per request.
+func (ws *WebServer) loggingMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        start := time.Now()
+        rec := &statusRecorder{ResponseWriter: w}
+
+        next.ServeHTTP(rec, r)
+
+        ws.logger.Info("request",
+            "method", r.Method,
+            "path", r.URL.Path,
+            "status", rec.status,
+            "duration_ms", time.Since(start).Milliseconds(),
+            "bytes", rec.bytes,
+            "remote_addr", r.RemoteAddr,
+            "request_id", requestIDFromContext(r.Context()),
+        )
+    })
+}
+
+// gzipResponseWriter transparently compresses body writes with gzip.
+type gzipResponseWriter struct {
+    http.ResponseWriter
+    gz *gzip.Writer
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+    return g.gz.Write(b)
+}
+
+// gzipMiddleware compresses responses for clients that advertise gzip
+// support. The /ws endpoint is excluded since it hijacks the connection
+// rather than writing a regular response body.
+func gzipMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/ws" || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        w.Header().Set("Content-Encoding", "gzip")
+        w.Header().Del("Content-Length")
+
+        gz := gzip.NewWriter(w)
+        defer gz.Close()
+
+        next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, gz: gz}, r)
+    })
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+)
+
+//go:embed templates/index.html
+var embeddedTemplates embed.FS
+
+//go:embed static
+var embeddedStatic embed.FS
+
+const indexTemplatePath = "templates/index.html"
+
+// loadTemplates parses the index template. In dev mode it is re-read from
+// disk on every call; otherwise the embedded copy is parsed once at startup.
+func loadTemplates(dev bool) (*template.Template, error) {
+    if dev {
+        data, err := os.ReadFile(indexTemplatePath)
+        if err != nil {
+            return nil, err
+        }
+        return template.New("index.html").Parse(string(data))
+    }
+    return template.ParseFS(embeddedTemplates, indexTemplatePath)
+}
+
+// newStaticHandler builds the handler mounted at /static/. In dev mode it
+// serves straight off disk so edits show up without a rebuild; otherwise it
+// serves the assets embedded at build time.
+func newStaticHandler(dev bool) (http.Handler, error) {
+    if dev {
+        return http.FileServer(http.Dir("static")), nil
+    }
+
+    sub, err := fs.Sub(embeddedStatic, "static")
+    if err != nil {
+        return nil, err
+    }
+    return http.FileServer(http.FS(sub)), nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+// TestLoadTemplates checks that the index template parses and renders
+// ServerInfo data, both from the embedded copy and (in dev mode) from disk.
+func TestLoadTemplates(t *testing.T) {
+    for _, dev := range []bool{false, true} {
+        tmpl, err := loadTemplates(dev)
+        if err != nil {
+            t.Fatalf("loadTemplates(%v): %v", dev, err)
+        }
+
+        var buf strings.Builder
+        if err := tmpl.Execute(&buf, ServerInfo{Port: 8080, Status: "running"}); err != nil {
+            t.Fatalf("loadTemplates(%v): execute: %v", dev, err)
+        }
+        if !strings.Contains(buf.String(), "Go Web Server") {
+            t.Errorf("loadTemplates(%v): rendered output missing expected content", dev)
+        }
+    }
+}
+
+// TestNewStaticHandler checks that the /static handler serves static/robots.txt,
+// both from the embedded FS and (in dev mode) straight off disk.
+func TestNewStaticHandler(t *testing.T) {
+    for _, dev := range []bool{false, true} {
+        handler, err := newStaticHandler(dev)
+        if err != nil {
+            t.Fatalf("newStaticHandler(%v): %v", dev, err)
+        }
+
+        req := httptest.NewRequest(http.MethodGet, "/robots.txt", nil)
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+
+        if rec.Code != http.StatusOK {
+            t.Fatalf("newStaticHandler(%v): status = %d, want %d", dev, rec.Code, http.StatusOK)
+        }
+        if !strings.Contains(rec.Body.String(), "User-agent") {
+            t.Errorf("newStaticHandler(%v): body missing expected content, got %q", dev, rec.Body.String())
+        }
+    }
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+    // defaultTLSCacheDir is where an auto-generated self-signed certificate
+    // is cached when no cert/key paths are configured.
+    defaultTLSCacheDir = ".xweb-certs"
+
+    // defaultTLSRedirectPort is the secondary HTTP listener started
+    // alongside the TLS listener to redirect plain HTTP traffic. It must
+    // differ from PORT, since with TLS enabled the main listener binds
+    // cfg.Port (defaulting to PORT) for HTTPS while this one stays plain HTTP.
+    defaultTLSRedirectPort = 8081
+
+    selfSignedCertValidity = 365 * 24 * time.Hour
+)
+
+// resolveTLSFiles returns a cert/key pair to serve. If cfg supplies explicit
+// paths those are used as-is; otherwise a self-signed pair is generated (or
+// reused, if one was already cached from a previous run) under cfg.CacheDir.
+func resolveTLSFiles(cfg TLSConfig, host string) (certFile, keyFile string, err error) {
+    if cfg.CertFile != "" && cfg.KeyFile != "" {
+        return cfg.CertFile, cfg.KeyFile, nil
+    }
+
+    cacheDir := cfg.CacheDir
+    if cacheDir == "" {
+        cacheDir = defaultTLSCacheDir
+    }
+    certFile = filepath.Join(cacheDir, "cert.pem")
+    keyFile = filepath.Join(cacheDir, "key.pem")
+
+    if _, err := os.Stat(certFile); err == nil {
+        if _, err := os.Stat(keyFile); err == nil {
+            return certFile, keyFile, nil
+        }
+    }
+
+    if err := generateSelfSignedCert(certFile, keyFile, host); err != nil {
+        return "", "", err
+    }
+    return certFile, keyFile, nil
+}
+
+// generateSelfSignedCert creates an ECDSA key and a self-signed certificate
+// valid for about a year, with SANs for host and the loopback addresses, and
+// PEM-encodes both to certFile and keyFile.
+func generateSelfSignedCert(certFile, keyFile, host string) error {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        return fmt.Errorf("generating key: %w", err)
+    }
+
+    serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+    if err != nil {
+        return fmt.Errorf("generating serial number: %w", err)
+    }
+
+    template := x509.Certificate{
+        SerialNumber: serial,
+        Subject:      pkix.Name{CommonName: host},
+        NotBefore:    time.Now(),
+        NotAfter:     time.Now().Add(selfSignedCertValidity),
+        KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+        DNSNames:     []string{host},
+        IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+    }
+
+    der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+    if err != nil {
+        return fmt.Errorf("creating certificate: %w", err)
+    }
+
+    if err := os.MkdirAll(filepath.Dir(certFile), 0o755); err != nil {
+        return fmt.Errorf("creating TLS cache dir: %w", err)
+    }
+
+    certOut, err := os.Create(certFile)
+    if err != nil {
+        return fmt.Errorf("creating cert file: %w", err)
+    }
+    defer certOut.Close()
+    if err := pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+        return fmt.Errorf("encoding certificate: %w", err)
+    }
+
+    keyBytes, err := x509.MarshalECPrivateKey(key)
+    if err != nil {
+        return fmt.Errorf("marshaling key: %w", err)
+    }
+
+    keyOut, err := os.OpenFile(keyFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+    if err != nil {
+        return fmt.Errorf("creating key file: %w", err)
+    }
+    defer keyOut.Close()
+    if err := pem.Encode(keyOut, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}); err != nil {
+        return fmt.Errorf("encoding key: %w", err)
+    }
+
+    return nil
+}
+
+// redirectHandler answers every request with a redirect to the HTTPS
+// equivalent, used for the secondary HTTP listener started when TLS is enabled.
+func (ws *WebServer) redirectHandler() http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        target := fmt.Sprintf("https://%s:%d%s", ws.cfg.Host, ws.cfg.Port, r.URL.RequestURI())
+        http.Redirect(w, r, target, http.StatusMovedPermanently)
+    })
+}
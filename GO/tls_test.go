@@ -0,0 +1,70 @@
+package main
+
+import (
+    "crypto/x509"
+    "encoding/pem"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+// TestGenerateSelfSignedCert checks that the generated certificate is a
+// plain end-entity cert (not a CA) with the expected SANs and validity.
+func TestGenerateSelfSignedCert(t *testing.T) {
+    dir := t.TempDir()
+    certFile := filepath.Join(dir, "cert.pem")
+    keyFile := filepath.Join(dir, "key.pem")
+
+    if err := generateSelfSignedCert(certFile, keyFile, "example.com"); err != nil {
+        t.Fatalf("generateSelfSignedCert: %v", err)
+    }
+
+    certPEM, err := os.ReadFile(certFile)
+    if err != nil {
+        t.Fatalf("reading cert file: %v", err)
+    }
+    block, _ := pem.Decode(certPEM)
+    if block == nil {
+        t.Fatal("cert file contains no PEM block")
+    }
+    cert, err := x509.ParseCertificate(block.Bytes)
+    if err != nil {
+        t.Fatalf("parsing certificate: %v", err)
+    }
+
+    if cert.IsCA {
+        t.Error("generated cert should not be a CA")
+    }
+
+    wantDNS := false
+    for _, name := range cert.DNSNames {
+        if name == "example.com" {
+            wantDNS = true
+        }
+    }
+    if !wantDNS {
+        t.Errorf("DNSNames = %v, want to include example.com", cert.DNSNames)
+    }
+
+    wantIPs := map[string]bool{"127.0.0.1": false, "::1": false}
+    for _, ip := range cert.IPAddresses {
+        if _, ok := wantIPs[ip.String()]; ok {
+            wantIPs[ip.String()] = true
+        }
+    }
+    for ip, found := range wantIPs {
+        if !found {
+            t.Errorf("IPAddresses missing %s, got %v", ip, cert.IPAddresses)
+        }
+    }
+
+    validity := cert.NotAfter.Sub(cert.NotBefore)
+    if validity < 364*24*time.Hour || validity > 366*24*time.Hour {
+        t.Errorf("validity = %v, want ~365 days", validity)
+    }
+
+    if _, err := os.ReadFile(keyFile); err != nil {
+        t.Errorf("reading key file: %v", err)
+    }
+}
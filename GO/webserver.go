@@ -1,16 +1,18 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
-	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -18,6 +20,10 @@ import (
 const (
     PORT = 8080
     HOST = "localhost"
+
+    // defaultShutdownTimeout is the default for Config.ShutdownTimeout, which
+    // bounds how long Stop waits for in-flight requests to drain.
+    defaultShutdownTimeout = 10 * time.Second
 )
 
 // ServerInfo represents the server information for the API response
@@ -41,25 +47,58 @@ type APIResponse struct {
 
 // WebServer handles HTTP requests and server management
 type WebServer struct {
-    server *http.Server
-    mux    *http.ServeMux
+    cfg            *Config
+    server         *http.Server
+    redirectServer *http.Server
+    mux            *http.ServeMux
+    hub            *wsHub
+    logger         *slog.Logger
+    middlewares    []Middleware
+    metrics        *metricsRegistry
+    tmpl           *template.Template
+    staticHandler  http.Handler
+    startTime      time.Time
+    requestCount   int64
 }
 
-// NewWebServer creates a new web server instance
-func NewWebServer() *WebServer {
+// NewWebServer creates a new web server instance from cfg
+func NewWebServer(cfg *Config) *WebServer {
     mux := http.NewServeMux()
     server := &http.Server{
-        Addr:         fmt.Sprintf("%s:%d", HOST, PORT),
+        Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
         Handler:      mux,
-        ReadTimeout:  10 * time.Second,
-        WriteTimeout: 10 * time.Second,
-        IdleTimeout:  120 * time.Second,
+        ReadTimeout:  cfg.ReadTimeout,
+        WriteTimeout: cfg.WriteTimeout,
+        IdleTimeout:  cfg.IdleTimeout,
+    }
+
+    tmpl, err := loadTemplates(cfg.Dev)
+    if err != nil {
+        log.Fatalf("Error loading templates: %v", err)
+    }
+
+    staticHandler, err := newStaticHandler(cfg.Dev)
+    if err != nil {
+        log.Fatalf("Error setting up static handler: %v", err)
     }
 
     ws := &WebServer{
-        server: server,
-        mux:    mux,
+        cfg:           cfg,
+        server:        server,
+        mux:           mux,
+        logger:        slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+        metrics:       newMetricsRegistry(),
+        tmpl:          tmpl,
+        staticHandler: staticHandler,
+        startTime:     time.Now(),
     }
+    ws.hub = newWsHub(ws)
+
+    ws.Use(ws.recoveryMiddleware)
+    ws.Use(requestIDMiddleware)
+    ws.Use(ws.loggingMiddleware)
+    ws.Use(ws.metricsMiddleware)
+    ws.Use(gzipMiddleware)
 
     ws.setupRoutes()
     return ws
@@ -69,22 +108,76 @@ func NewWebServer() *WebServer {
 func (ws *WebServer) setupRoutes() {
     ws.mux.HandleFunc("/", ws.handleRoot)
     ws.mux.HandleFunc("/api", ws.handleAPI)
+    ws.mux.HandleFunc("/ws", ws.handleWS)
+    ws.mux.Handle("/static/", http.StripPrefix("/static/", ws.staticHandler))
+    ws.mux.HandleFunc("/metrics", ws.handleMetrics)
 }
 
-// Start begins listening for HTTP requests
+// Start begins listening for HTTP requests, or HTTPS requests plus a
+// redirect listener when TLS is enabled.
 func (ws *WebServer) Start() error {
-    fmt.Printf("Web server started on http://%s:%d\n", HOST, PORT)
-    fmt.Printf("Main page: http://%s:%d/\n", HOST, PORT)
-    fmt.Printf("API endpoint: http://%s:%d/api\n", HOST, PORT)
+    scheme, wsScheme := "http", "ws"
+    if ws.cfg.TLS.Enabled {
+        scheme, wsScheme = "https", "wss"
+    }
+    fmt.Printf("Web server started on %s://%s:%d\n", scheme, ws.cfg.Host, ws.cfg.Port)
+    fmt.Printf("Main page: %s://%s:%d/\n", scheme, ws.cfg.Host, ws.cfg.Port)
+    fmt.Printf("API endpoint: %s://%s:%d/api\n", scheme, ws.cfg.Host, ws.cfg.Port)
+    fmt.Printf("Metrics stream: %s://%s:%d/ws\n", wsScheme, ws.cfg.Host, ws.cfg.Port)
     fmt.Println("Press Ctrl+C to stop the server")
 
-    return ws.server.ListenAndServe()
+    ws.server.Handler = ws.buildHandler()
+
+    go ws.hub.run()
+
+    if !ws.cfg.TLS.Enabled {
+        return ws.server.ListenAndServe()
+    }
+
+    certFile, keyFile, err := resolveTLSFiles(ws.cfg.TLS, ws.cfg.Host)
+    if err != nil {
+        return fmt.Errorf("resolving TLS certificate: %w", err)
+    }
+
+    ws.redirectServer = &http.Server{
+        Addr:    fmt.Sprintf("%s:%d", ws.cfg.Host, ws.cfg.TLS.RedirectPort),
+        Handler: ws.redirectHandler(),
+    }
+    go func() {
+        if err := ws.redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+            ws.logger.Error("HTTP redirect listener error", "error", err)
+        }
+    }()
+    fmt.Printf("HTTP redirect: http://%s:%d -> %s://%s:%d\n",
+        ws.cfg.Host, ws.cfg.TLS.RedirectPort, scheme, ws.cfg.Host, ws.cfg.Port)
+
+    return ws.server.ListenAndServeTLS(certFile, keyFile)
 }
 
-// Stop gracefully shuts down the server
-func (ws *WebServer) Stop() error {
+// Shutdown drains in-flight requests and closes the server (and, if TLS is
+// enabled, the redirect listener) once ctx is done or every connection has
+// finished, whichever comes first.
+func (ws *WebServer) Shutdown(ctx context.Context) error {
     fmt.Println("\nShutting down server...")
-    return ws.server.Close()
+    if ws.redirectServer != nil {
+        if err := ws.redirectServer.Shutdown(ctx); err != nil {
+            ws.logger.Error("error shutting down redirect listener", "error", err)
+        }
+    }
+    err := ws.server.Shutdown(ctx)
+    if err != nil && ctx.Err() != nil {
+        // The drain deadline passed: force-close the connections still open.
+        ws.server.Close()
+    }
+    return err
+}
+
+// Stop gracefully shuts down the server, allowing up to cfg.ShutdownTimeout for
+// in-flight requests to drain before the connections are forced closed.
+func (ws *WebServer) Stop() error {
+    ctx, cancel := context.WithTimeout(context.Background(), ws.cfg.ShutdownTimeout)
+    defer cancel()
+    return ws.Shutdown(ctx)
 }
 
 // handleRoot serves the HTML page
@@ -93,16 +186,16 @@ func (ws *WebServer) handleRoot(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
         return
     }
+    atomic.AddInt64(&ws.requestCount, 1)
 
     htmlContent := ws.createHTMLResponse()
 
     w.Header().Set("Content-Type", "text/html; charset=utf-8")
     w.Header().Set("Connection", "close")
-    w.Header().Set("Content-Length", strconv.Itoa(len(htmlContent)))
     w.WriteHeader(http.StatusOK)
 
     if _, err := w.Write([]byte(htmlContent)); err != nil {
-        log.Printf("Error writing HTML response: %v", err)
+        ws.logger.Error("error writing HTML response", "error", err)
     }
 }
 
@@ -112,193 +205,45 @@ func (ws *WebServer) handleAPI(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
         return
     }
+    atomic.AddInt64(&ws.requestCount, 1)
 
     apiResponse := ws.createAPIResponse()
 
     jsonData, err := json.MarshalIndent(apiResponse, "", "  ")
     if err != nil {
         http.Error(w, "Internal server error", http.StatusInternalServerError)
-        log.Printf("Error marshaling JSON: %v", err)
+        ws.logger.Error("error marshaling JSON", "error", err)
         return
     }
 
     w.Header().Set("Content-Type", "application/json; charset=utf-8")
     w.Header().Set("Connection", "close")
-    w.Header().Set("Content-Length", strconv.Itoa(len(jsonData)))
     w.WriteHeader(http.StatusOK)
 
     if _, err := w.Write(jsonData); err != nil {
-        log.Printf("Error writing JSON response: %v", err)
+        ws.logger.Error("error writing JSON response", "error", err)
     }
 }
 
-// createHTMLResponse generates the HTML page content
+// createHTMLResponse renders the index template with the current ServerInfo.
+// In dev mode the template is re-read from disk on every call so edits are
+// picked up without a restart; otherwise the template parsed once at startup is reused.
 func (ws *WebServer) createHTMLResponse() string {
-    const htmlTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Go Web Server</title>
-    <style>
-        body {
-            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
-            margin: 0;
-            padding: 40px;
-            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
-            min-height: 100vh;
-            color: #333;
+    tmpl := ws.tmpl
+    if ws.cfg.Dev {
+        reloaded, err := loadTemplates(true)
+        if err != nil {
+            ws.logger.Error("error reloading HTML template", "error", err)
+            return "<html><body><h1>Template Error</h1></body></html>"
         }
-        .container {
-            max-width: 900px;
-            margin: 0 auto;
-            background-color: white;
-            padding: 40px;
-            border-radius: 15px;
-            box-shadow: 0 10px 30px rgba(0,0,0,0.2);
-        }
-        h1 {
-            color: #2c3e50;
-            text-align: center;
-            margin-bottom: 10px;
-            font-size: 2.5em;
-            font-weight: 300;
-        }
-        .language-badge {
-            display: inline-block;
-            background: linear-gradient(45deg, #f7df1e, #f0db4f);
-            color: black;
-            padding: 8px 16px;
-            border-radius: 25px;
-            font-size: 0.9em;
-            font-weight: bold;
-            margin-left: 10px;
-            box-shadow: 0 2px 10px rgba(0,0,0,0.2);
-        }
-        h2 {
-            color: #34495e;
-            border-bottom: 3px solid #3498db;
-            padding-bottom: 10px;
-            margin-top: 40px;
-        }
-        .info-grid {
-            display: grid;
-            grid-template-columns: auto 1fr;
-            gap: 15px 25px;
-            margin: 25px 0;
-            background: linear-gradient(135deg, #f8f9fa, #e9ecef);
-            padding: 25px;
-            border-radius: 10px;
-            border-left: 5px solid #3498db;
-        }
-        .info-label {
-            font-weight: bold;
-            color: #2c3e50;
-        }
-        .info-value {
-            color: #34495e;
-        }
-        a {
-            color: #3498db;
-            text-decoration: none;
-            font-weight: 500;
-            transition: all 0.3s ease;
-        }
-        a:hover {
-            color: #2980b9;
-            text-decoration: underline;
-        }
-        #browser {
-            background: linear-gradient(135deg, #e8f4f8, #d1ecf1);
-            padding: 20px;
-            border-radius: 10px;
-            margin-top: 15px;
-            border-left: 5px solid #17a2b8;
-            font-family: 'Courier New', monospace;
-            font-size: 0.9em;
-        }
-        .footer {
-            text-align: center;
-            margin-top: 40px;
-            padding-top: 20px;
-            border-top: 1px solid #dee2e6;
-            color: #6c757d;
-            font-size: 0.9em;
-        }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <h1>Hello, World! <span class="language-badge">Go</span></h1>
-        
-        <h2>Server Information</h2>
-        <div class="info-grid">
-            <span class="info-label">Port:</span>
-            <span class="info-value">{{.Port}}</span>
-            <span class="info-label">Platform:</span>
-            <span class="info-value">{{.Platform}}</span>
-            <span class="info-label">Operating System:</span>
-            <span class="info-value">{{.OS}}</span>
-            <span class="info-label">Go Version:</span>
-            <span class="info-value">{{.GoVersion}}</span>
-            <span class="info-label">Architecture:</span>
-            <span class="info-value">{{.Architecture}}</span>
-            <span class="info-label">API Endpoint:</span>
-            <span class="info-value"><a href='/api'>/api</a></span>
-        </div>
-        
-        <h2>Browser Information</h2>
-        <div id='browser'>
-            <em>JavaScript required to display browser information</em>
-        </div>
-
-        <div class="footer">
-            <p>Multi-Language Web Server Collection | JavaScript Implementation</p>
-        </div>
-
-        <script>
-            const browserInfo = document.getElementById('browser');
-            const info = [
-                '<strong>User-Agent:</strong> ' + navigator.userAgent,
-                '<strong>Platform:</strong> ' + navigator.platform,
-                '<strong>Language:</strong> ' + navigator.language,
-                '<strong>Languages:</strong> ' + navigator.languages.join(', '),
-                '<strong>Cookies enabled:</strong> ' + navigator.cookieEnabled,
-                '<strong>Screen resolution:</strong> ' + screen.width + 'x' + screen.height,
-                '<strong>Color depth:</strong> ' + screen.colorDepth + ' bits',
-                '<strong>Timezone:</strong> ' + Intl.DateTimeFormat().resolvedOptions().timeZone,
-                '<strong>Online status:</strong> ' + (navigator.onLine ? 'Online' : 'Offline'),
-                '<strong>Hardware concurrency:</strong> ' + (navigator.hardwareConcurrency || 'Unknown') + ' cores'
-            ];
-            browserInfo.innerHTML = info.join('<br>');
-        </script>
-    </div>
-</body>
-</html>`
-
-    tmpl, err := template.New("html").Parse(htmlTemplate)
-    if err != nil {
-        log.Printf("Error parsing HTML template: %v", err)
-        return "<html><body><h1>Template Error</h1></body></html>"
+        tmpl = reloaded
     }
 
-    data := struct {
-        Port         int
-        Platform     string
-        OS           string
-        GoVersion    string
-        Architecture string
-    }{
-        Port:         PORT,
-        Platform:     ws.getPlatformName(),
-        OS:           ws.getOSName(),
-        GoVersion:    runtime.Version(),
-        Architecture: runtime.GOARCH,
-    }
+    data := ws.createAPIResponse().ServerInfo
 
     var buf strings.Builder
     if err := tmpl.Execute(&buf, data); err != nil {
-        log.Printf("Error executing HTML template: %v", err)
+        ws.logger.Error("error executing HTML template", "error", err)
         return "<html><body><h1>Template Execution Error</h1></body></html>"
     }
 
@@ -311,7 +256,7 @@ func (ws *WebServer) createAPIResponse() APIResponse {
 
     return APIResponse{
         ServerInfo: ServerInfo{
-            Port:         PORT,
+            Port:         ws.cfg.Port,
             Platform:     ws.getPlatformID(),
             OS:           ws.getOSName(),
             GoVersion:    runtime.Version(),
@@ -325,6 +270,33 @@ func (ws *WebServer) createAPIResponse() APIResponse {
     }
 }
 
+// metricsTick is the payload pushed to every /ws subscriber once per second.
+type metricsTick struct {
+    ServerInfo    ServerInfo `json:"server_info"`
+    Goroutines    int        `json:"goroutines"`
+    AllocBytes    uint64     `json:"alloc_bytes"`
+    SysBytes      uint64     `json:"sys_bytes"`
+    NumGC         uint32     `json:"num_gc"`
+    UptimeSeconds float64    `json:"uptime_seconds"`
+    RequestCount  int64      `json:"request_count"`
+}
+
+// createMetricsTick snapshots the current server and runtime stats for streaming over /ws.
+func (ws *WebServer) createMetricsTick() metricsTick {
+    var mem runtime.MemStats
+    runtime.ReadMemStats(&mem)
+
+    return metricsTick{
+        ServerInfo:    ws.createAPIResponse().ServerInfo,
+        Goroutines:    runtime.NumGoroutine(),
+        AllocBytes:    mem.Alloc,
+        SysBytes:      mem.Sys,
+        NumGC:         mem.NumGC,
+        UptimeSeconds: time.Since(ws.startTime).Seconds(),
+        RequestCount:  atomic.LoadInt64(&ws.requestCount),
+    }
+}
+
 // getPlatformName returns a human-readable platform name
 func (ws *WebServer) getPlatformName() string {
     switch runtime.GOOS {
@@ -398,31 +370,55 @@ func (ws *WebServer) getLinuxDistribution() string {
     return ""
 }
 
-// setupSignalHandling configures graceful shutdown on interrupt signals
-func setupSignalHandling(server *WebServer) {
+// setupSignalHandling configures graceful shutdown on interrupt signals and
+// reports the outcome of the drain on the returned channel.
+func setupSignalHandling(server *WebServer) <-chan error {
     sigChan := make(chan os.Signal, 1)
     signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+    done := make(chan error, 1)
     go func() {
         sig := <-sigChan
-        log.Printf("Received signal: %v", sig)
-        if err := server.Stop(); err != nil {
-            log.Printf("Error stopping server: %v", err)
-        }
-        os.Exit(0)
+        server.logger.Info("received signal", "signal", sig)
+        done <- server.Stop()
     }()
+    return done
 }
 
 func main() {
+    cfg, err := LoadConfig(os.Args[1:])
+    if err != nil {
+        log.Fatalf("Error loading config: %v", err)
+    }
+
     // Create and configure the web server
-    server := NewWebServer()
+    server := NewWebServer(cfg)
 
     // Setup graceful shutdown
-    setupSignalHandling(server)
+    shutdownDone := setupSignalHandling(server)
 
     // Start the server
-    if err := server.Start(); err != nil && err != http.ErrServerClosed {
-        log.Printf("Server error: %v", err)
-        os.Exit(1)
+    serverErr := make(chan error, 1)
+    go func() {
+        serverErr <- server.Start()
+    }()
+
+    select {
+    case err := <-serverErr:
+        if err != nil && err != http.ErrServerClosed {
+            server.logger.Error("server error", "error", err)
+            os.Exit(1)
+        }
+        // Start returns ErrServerClosed as soon as Shutdown begins, so wait
+        // for the drain itself to finish before exiting.
+        if err := <-shutdownDone; err != nil {
+            server.logger.Error("error draining in-flight requests", "error", err)
+            os.Exit(1)
+        }
+    case err := <-shutdownDone:
+        if err != nil {
+            server.logger.Error("error draining in-flight requests", "error", err)
+            os.Exit(1)
+        }
     }
 }
\ No newline at end of file
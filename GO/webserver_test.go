@@ -0,0 +1,65 @@
+package main
+
+import (
+    "context"
+    "net"
+    "net/http"
+    "testing"
+    "time"
+)
+
+// TestShutdownWaitsForInFlightRequest checks that Shutdown blocks until a
+// request already being handled finishes, rather than cutting it off.
+func TestShutdownWaitsForInFlightRequest(t *testing.T) {
+    cfg := defaultConfig()
+    cfg.Host = "127.0.0.1"
+    cfg.Port = 0
+    ws := NewWebServer(cfg)
+
+    started := make(chan struct{})
+    release := make(chan struct{})
+    ws.mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+        close(started)
+        <-release
+        w.WriteHeader(http.StatusOK)
+    })
+    ws.server.Handler = ws.buildHandler()
+
+    ln, err := net.Listen("tcp", ws.server.Addr)
+    if err != nil {
+        t.Fatalf("net.Listen: %v", err)
+    }
+    go ws.server.Serve(ln)
+
+    addr := ln.Addr().String()
+    clientDone := make(chan error, 1)
+    go func() {
+        resp, err := http.Get("http://" + addr + "/slow")
+        if err == nil {
+            resp.Body.Close()
+        }
+        clientDone <- err
+    }()
+
+    <-started
+
+    shutdownDone := make(chan error, 1)
+    go func() {
+        shutdownDone <- ws.Shutdown(context.Background())
+    }()
+
+    select {
+    case <-shutdownDone:
+        t.Fatal("Shutdown returned before the in-flight handler finished")
+    case <-time.After(100 * time.Millisecond):
+    }
+
+    close(release)
+
+    if err := <-shutdownDone; err != nil {
+        t.Fatalf("Shutdown: %v", err)
+    }
+    if err := <-clientDone; err != nil {
+        t.Fatalf("client request: %v", err)
+    }
+}
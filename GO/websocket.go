@@ -0,0 +1,331 @@
+package main
+
+import (
+    "bufio"
+    "crypto/sha1"
+    "encoding/base64"
+    "encoding/binary"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+)
+
+// wsWriteWait bounds how long a single write to a client connection may take.
+const wsWriteWait = 10 * time.Second
+
+// wsMaxMessageSize bounds the payload of a single inbound frame. Without
+// this, a client claiming a near-2^63 length in its frame header could force
+// an allocation of that size before a single payload byte is read.
+const wsMaxMessageSize = 64 * 1024
+
+// wsHandshakeGUID is the fixed GUID RFC 6455 defines for computing
+// Sec-WebSocket-Accept from the client's Sec-WebSocket-Key.
+const wsHandshakeGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes, as defined by RFC 6455 section 5.2.
+const (
+    wsOpText  = 0x1
+    wsOpClose = 0x8
+    wsOpPing  = 0x9
+    wsOpPong  = 0xA
+)
+
+// wsConn is a bare-bones RFC 6455 connection: just enough handshake and
+// frame handling to stream JSON ticks and answer pings, so the module has no
+// hard dependency on an external WebSocket library.
+type wsConn struct {
+    netConn  net.Conn
+    reader   *bufio.Reader
+    writeMu  sync.Mutex
+    readWait time.Duration // idle read deadline, reset on every frame received
+}
+
+// upgradeWebSocket validates the handshake headers, hijacks the underlying
+// connection, and writes the 101 response, returning a wsConn ready to frame
+// messages over the raw socket.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+    if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+        !strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+        return nil, errors.New("websocket: not an upgrade request")
+    }
+    key := r.Header.Get("Sec-WebSocket-Key")
+    if key == "" {
+        return nil, errors.New("websocket: missing Sec-WebSocket-Key")
+    }
+
+    hj, ok := w.(http.Hijacker)
+    if !ok {
+        return nil, errors.New("websocket: response writer does not support hijacking")
+    }
+    netConn, rw, err := hj.Hijack()
+    if err != nil {
+        return nil, err
+    }
+
+    accept := wsAcceptKey(key)
+    response := "HTTP/1.1 101 Switching Protocols\r\n" +
+        "Upgrade: websocket\r\n" +
+        "Connection: Upgrade\r\n" +
+        "Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+    if _, err := rw.WriteString(response); err != nil {
+        netConn.Close()
+        return nil, err
+    }
+    if err := rw.Flush(); err != nil {
+        netConn.Close()
+        return nil, err
+    }
+
+    return &wsConn{netConn: netConn, reader: rw.Reader}, nil
+}
+
+// wsAcceptKey derives Sec-WebSocket-Accept from a client's Sec-WebSocket-Key
+// as specified in RFC 6455 section 1.3.
+func wsAcceptKey(key string) string {
+    h := sha1.New()
+    h.Write([]byte(key + wsHandshakeGUID))
+    return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// writeFrame sends a single unmasked server-to-client frame. Server frames
+// are never masked, per RFC 6455.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+    c.writeMu.Lock()
+    defer c.writeMu.Unlock()
+
+    length := len(payload)
+    var header []byte
+    switch {
+    case length <= 125:
+        header = []byte{0x80 | opcode, byte(length)}
+    case length <= 65535:
+        header = make([]byte, 4)
+        header[0] = 0x80 | opcode
+        header[1] = 126
+        binary.BigEndian.PutUint16(header[2:], uint16(length))
+    default:
+        header = make([]byte, 10)
+        header[0] = 0x80 | opcode
+        header[1] = 127
+        binary.BigEndian.PutUint64(header[2:], uint64(length))
+    }
+
+    if _, err := c.netConn.Write(header); err != nil {
+        return err
+    }
+    _, err := c.netConn.Write(payload)
+    return err
+}
+
+// readMessage reads the next client frame, unmasking its payload as required
+// by RFC 6455, transparently answering pings with pongs until a data or
+// close frame arrives. Every frame (including pongs) resets the connection's
+// read deadline, so a peer that stops responding to pings times out instead
+// of leaking the connection and its readPump goroutine forever. Frames
+// larger than wsMaxMessageSize are rejected before the payload is allocated.
+func (c *wsConn) readMessage() (opcode byte, payload []byte, err error) {
+    for {
+        if c.readWait > 0 {
+            c.netConn.SetReadDeadline(time.Now().Add(c.readWait))
+        }
+
+        header := make([]byte, 2)
+        if _, err := io.ReadFull(c.reader, header); err != nil {
+            return 0, nil, err
+        }
+
+        opcode = header[0] & 0x0f
+        masked := header[1]&0x80 != 0
+        length := uint64(header[1] & 0x7f)
+
+        switch length {
+        case 126:
+            ext := make([]byte, 2)
+            if _, err := io.ReadFull(c.reader, ext); err != nil {
+                return 0, nil, err
+            }
+            length = uint64(binary.BigEndian.Uint16(ext))
+        case 127:
+            ext := make([]byte, 8)
+            if _, err := io.ReadFull(c.reader, ext); err != nil {
+                return 0, nil, err
+            }
+            length = binary.BigEndian.Uint64(ext)
+        }
+        if length > wsMaxMessageSize {
+            return 0, nil, fmt.Errorf("websocket: frame length %d exceeds max %d", length, wsMaxMessageSize)
+        }
+
+        var maskKey [4]byte
+        if masked {
+            if _, err := io.ReadFull(c.reader, maskKey[:]); err != nil {
+                return 0, nil, err
+            }
+        }
+
+        payload = make([]byte, length)
+        if _, err := io.ReadFull(c.reader, payload); err != nil {
+            return 0, nil, err
+        }
+        if masked {
+            for i := range payload {
+                payload[i] ^= maskKey[i%4]
+            }
+        }
+
+        switch opcode {
+        case wsOpPing:
+            if err := c.writeFrame(wsOpPong, payload); err != nil {
+                return 0, nil, err
+            }
+            continue
+        case wsOpPong:
+            continue
+        default:
+            return opcode, payload, nil
+        }
+    }
+}
+
+func (c *wsConn) close() error {
+    return c.netConn.Close()
+}
+
+// wsClient wraps a single /ws connection and its outbound send buffer.
+type wsClient struct {
+    conn *wsConn
+    send chan []byte
+}
+
+// wsHub manages the set of connected metrics-stream clients and broadcasts
+// a tick to each of them once per second.
+type wsHub struct {
+    mu      sync.Mutex
+    clients map[*wsClient]bool
+    ws      *WebServer
+}
+
+// newWsHub creates a hub bound to ws, used to build each tick's payload.
+func newWsHub(ws *WebServer) *wsHub {
+    return &wsHub{
+        clients: make(map[*wsClient]bool),
+        ws:      ws,
+    }
+}
+
+// register adds a client to the broadcast set.
+func (h *wsHub) register(c *wsClient) {
+    h.mu.Lock()
+    h.clients[c] = true
+    h.mu.Unlock()
+}
+
+// unregister removes a client and closes its send channel, if still present.
+func (h *wsHub) unregister(c *wsClient) {
+    h.mu.Lock()
+    if _, ok := h.clients[c]; ok {
+        delete(h.clients, c)
+        close(c.send)
+    }
+    h.mu.Unlock()
+}
+
+// broadcast fans a payload out to every client's send buffer, dropping any
+// client that isn't keeping up rather than blocking the tick loop.
+func (h *wsHub) broadcast(payload []byte) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for c := range h.clients {
+        select {
+        case c.send <- payload:
+        default:
+            delete(h.clients, c)
+            close(c.send)
+        }
+    }
+}
+
+// run pushes a fresh metrics tick to every connected client once per second.
+func (h *wsHub) run() {
+    ticker := time.NewTicker(time.Second)
+    defer ticker.Stop()
+
+    for range ticker.C {
+        payload, err := json.Marshal(h.ws.createMetricsTick())
+        if err != nil {
+            h.ws.logger.Error("error marshaling metrics tick", "error", err)
+            continue
+        }
+        h.broadcast(payload)
+    }
+}
+
+// handleWS upgrades the connection to a WebSocket and streams metrics ticks
+// to it until the client disconnects.
+func (ws *WebServer) handleWS(w http.ResponseWriter, r *http.Request) {
+    conn, err := upgradeWebSocket(w, r)
+    if err != nil {
+        ws.logger.Error("error upgrading websocket connection", "error", err)
+        return
+    }
+
+    conn.readWait = ws.server.IdleTimeout
+    client := &wsClient{conn: conn, send: make(chan []byte, 16)}
+    ws.hub.register(client)
+
+    go client.writePump(ws.server.IdleTimeout)
+    client.readPump(ws.hub)
+}
+
+// readPump discards inbound messages but keeps reading (and thus answering
+// pings and resetting the read deadline) until the client disconnects, sends
+// a close frame, or goes quiet longer than the server's IdleTimeout.
+func (c *wsClient) readPump(h *wsHub) {
+    defer func() {
+        h.unregister(c)
+        c.conn.close()
+    }()
+
+    for {
+        opcode, _, err := c.conn.readMessage()
+        if err != nil || opcode == wsOpClose {
+            break
+        }
+    }
+}
+
+// writePump delivers buffered sends to the client and pings it periodically,
+// tied to the server's IdleTimeout so the connection never idles out.
+func (c *wsClient) writePump(idleTimeout time.Duration) {
+    pingPeriod := idleTimeout * 9 / 10
+    ticker := time.NewTicker(pingPeriod)
+    defer func() {
+        ticker.Stop()
+        c.conn.close()
+    }()
+
+    for {
+        select {
+        case msg, ok := <-c.send:
+            c.conn.netConn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+            if !ok {
+                c.conn.writeFrame(wsOpClose, nil)
+                return
+            }
+            if err := c.conn.writeFrame(wsOpText, msg); err != nil {
+                return
+            }
+        case <-ticker.C:
+            c.conn.netConn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+            if err := c.conn.writeFrame(wsOpPing, nil); err != nil {
+                return
+            }
+        }
+    }
+}